@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/akutz/goof"
+	"github.com/spf13/cobra"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+
+	"github.com/codedellemc/rexray/cli/cli/manifest"
+	"github.com/codedellemc/rexray/cli/cli/reconciler"
+)
+
+// defaultApplyTableSpec is used when the user runs `rexray apply` without
+// specifying --format/--table.
+const defaultApplyTableSpec = "{{.Name}}\t{{.Action}}\t{{.Result}}"
+
+func init() {
+	initCmdFuncs = append(initCmdFuncs, func(c *CLI) {
+		c.initApplyCmdsAndFlags()
+	})
+}
+
+func (c *CLI) initApplyCmdsAndFlags() {
+	c.initApplyCmd()
+	c.initGenerateCmd()
+}
+
+func (c *CLI) initApplyCmd() {
+	c.applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Reconcile a declarative volume manifest against libStorage",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runApply(); err != nil {
+				c.fail(err)
+			}
+		},
+		PreRun: c.preRunActivateLibStorage,
+	}
+	c.c.AddCommand(c.applyCmd)
+
+	c.applyCmd.Flags().StringVarP(
+		&c.manifestFile, "file", "m", "",
+		"The path to the volume manifest (YAML or JSON)")
+	c.applyCmd.Flags().BoolVarP(
+		&c.attach, "attach", "a", false,
+		"Attach and mount volumes that declare a mountPoint")
+	c.addOutputFormatFlag(c.applyCmd.Flags())
+	c.addDryRunFlag(c.applyCmd.Flags())
+	c.addContinueOnErrorFlag(c.applyCmd.Flags())
+	c.addIdempotentFlag(c.applyCmd.Flags())
+}
+
+func (c *CLI) initGenerateCmd() {
+	c.generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a volume manifest from the current libStorage state",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runGenerate(); err != nil {
+				c.fail(err)
+			}
+		},
+		PreRun: c.preRunActivateLibStorage,
+	}
+	c.c.AddCommand(c.generateCmd)
+
+	c.generateCmd.Flags().StringVarP(
+		&c.manifestFile, "file", "m", "",
+		"The path to write the generated manifest to (YAML or JSON)")
+}
+
+func (c *CLI) runApply() error {
+	if c.manifestFile == "" {
+		return goof.New("--file is required")
+	}
+
+	m, err := manifest.Load(c.manifestFile)
+	if err != nil {
+		return err
+	}
+
+	r := reconciler.New(c.r)
+	r.DryRun = c.dryRun
+	r.ContinueOnError = c.continueOnError
+	r.Idempotent = c.idempotent
+	r.Attach = c.attach
+
+	results, err := r.Reconcile(c.ctx, store(), m.Volumes)
+	if err != nil && !c.continueOnError {
+		return err
+	}
+
+	return c.printApplyResults(results)
+}
+
+func (c *CLI) runGenerate() error {
+	vols, err := c.r.Storage().Volumes(
+		c.ctx, &apitypes.VolumesOpts{Opts: store()})
+	if err != nil {
+		return err
+	}
+
+	m := &manifest.Manifest{Version: "v1"}
+	for _, vol := range vols {
+		spec := manifest.VolumeSpec{
+			Name:             vol.Name,
+			Size:             vol.Size,
+			IOPS:             vol.IOPS,
+			Type:             vol.Type,
+			Encrypted:        vol.Encrypted,
+			AvailabilityZone: vol.AvailabilityZone,
+			Labels:           vol.Fields,
+		}
+		if len(vol.Attachments) > 0 {
+			spec.MountPoint = vol.Attachments[0].MountPoint
+			spec.FsType = vol.Attachments[0].Fstype
+		}
+		m.Volumes = append(m.Volumes, spec)
+	}
+
+	if c.manifestFile == "" {
+		return manifest.Save(os.Stdout.Name(), m)
+	}
+	return manifest.Save(c.manifestFile, m)
+}
+
+// applyResultRow is the template-friendly shape rendered by
+// --format=tmpl/table/json/yaml for `rexray apply` output.
+type applyResultRow struct {
+	Name   string
+	Action string
+	Result string
+}
+
+// printApplyResults renders results through the shared output renderer,
+// defaulting to one name/action/result line per volume (no header line)
+// when the user hasn't specified --format/--table.
+func (c *CLI) printApplyResults(results []reconciler.Result) error {
+	rows := make([]applyResultRow, len(results))
+	for i, res := range results {
+		status := "ok"
+		if res.Error != nil {
+			status = res.Error.Error()
+		}
+		rows[i] = applyResultRow{
+			Name:   res.Name,
+			Action: string(res.Action),
+			Result: status,
+		}
+	}
+
+	format, table := c.outputFormat, c.outputTable
+	if (format == "" || format == "tmpl") && c.outputTemplate == "" {
+		format, table = "table", defaultApplyTableSpec
+	}
+
+	origFormat, origTable := c.outputFormat, c.outputTable
+	c.outputFormat, c.outputTable = format, table
+	defer func() { c.outputFormat, c.outputTable = origFormat, origTable }()
+
+	return c.marshalOutput(os.Stdout, rows)
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	gofig "github.com/akutz/gofig/types"
@@ -40,12 +41,24 @@ type CLI struct {
 	ctx                apitypes.Context
 	activateLibStorage bool
 
+	// completionActivateFailed remembers that
+	// ensureLibStorageActivatedForCompletion already tried and failed to
+	// activate libStorage, so repeated completion callbacks within the
+	// same process don't retry a connection that's already known to be
+	// unreachable.
+	completionActivateFailed bool
+
 	envCmd     *cobra.Command
 	versionCmd *cobra.Command
 
 	installCmd   *cobra.Command
 	uninstallCmd *cobra.Command
 
+	applyCmd    *cobra.Command
+	generateCmd *cobra.Command
+
+	completionCmd *cobra.Command
+
 	moduleCmd                *cobra.Command
 	moduleTypesCmd           *cobra.Command
 	moduleInstancesCmd       *cobra.Command
@@ -59,20 +72,26 @@ type CLI struct {
 	serviceStopCmd    *cobra.Command
 	serviceStatusCmd  *cobra.Command
 	serviceInitSysCmd *cobra.Command
+	serviceWatchCmd   *cobra.Command
 
 	adapterCmd             *cobra.Command
 	adapterGetTypesCmd     *cobra.Command
 	adapterGetInstancesCmd *cobra.Command
 
-	volumeCmd        *cobra.Command
-	volumeListCmd    *cobra.Command
-	volumeCreateCmd  *cobra.Command
-	volumeRemoveCmd  *cobra.Command
-	volumeAttachCmd  *cobra.Command
-	volumeDetachCmd  *cobra.Command
-	volumeMountCmd   *cobra.Command
-	volumeUnmountCmd *cobra.Command
-	volumePathCmd    *cobra.Command
+	volumeCmd            *cobra.Command
+	volumeListCmd        *cobra.Command
+	volumeCreateCmd      *cobra.Command
+	volumeRemoveCmd      *cobra.Command
+	volumeAttachCmd      *cobra.Command
+	volumeDetachCmd      *cobra.Command
+	volumeMountCmd       *cobra.Command
+	volumeUnmountCmd     *cobra.Command
+	volumePathCmd        *cobra.Command
+	volumeWatchCmd       *cobra.Command
+	volumeWatchAddCmd    *cobra.Command
+	volumeWatchListCmd   *cobra.Command
+	volumeWatchRemoveCmd *cobra.Command
+	volumeExecCmd        *cobra.Command
 
 	snapshotCmd       *cobra.Command
 	snapshotGetCmd    *cobra.Command
@@ -128,6 +147,14 @@ type CLI struct {
 	encrypted               bool
 	encryptionKey           string
 	idempotent              bool
+	manifestFile            string
+	outputTable             string
+	watchInterval           time.Duration
+	watchOnce               bool
+	keep                    bool
+	sigProxy                bool
+	noStdin                 bool
+	detachKeys              string
 }
 
 const (
@@ -179,6 +206,11 @@ func NewWithArgs(ctx apitypes.Context, a ...string) *CLI {
 		f(c)
 	}
 
+	// Dynamic completion wiring needs every command tree to already exist,
+	// so it runs as an explicit second pass rather than living in
+	// initCmdFuncs. See initDynamicCompletion's doc comment.
+	c.initDynamicCompletion()
+
 	c.initUsageTemplates()
 
 	return c
@@ -242,10 +274,16 @@ func (c *CLI) execute() {
 func (c *CLI) addOutputFormatFlag(fs *pflag.FlagSet) {
 	fs.StringVarP(
 		&c.outputFormat, "format", "f", "tmpl",
-		"The output format (tmpl, json, jsonp)")
+		"The output format (tmpl, json, jsonp, yaml, table)")
 	fs.StringVarP(
 		&c.outputTemplate, "template", "", "",
-		"The Go template to use when --format is set to 'tmpl'")
+		"The Go template to use when --format is set to 'tmpl'. "+
+			"Prefix with '@' to load the template from a file, "+
+			"e.g. --template @path/to/file.tmpl")
+	fs.StringVarP(
+		&c.outputTable, "table", "", "",
+		"The tab-delimited column spec to use when --format is set to "+
+			"'table', e.g. --table '{{.Name}}\\t{{.Size}}\\t{{.IOPS}}'")
 	fs.BoolVarP(
 		&c.outputTemplateTabs, "templateTabs", "", true,
 		"Set to true to use a Go tab writer with the output template")
@@ -388,6 +426,15 @@ func (c *CLI) checkCmdPermRequirements(cmd *cobra.Command) error {
 	return nil
 }
 
+func (c *CLI) fail(err error) {
+	if term.IsTerminal() {
+		printColorizedError(err)
+	} else {
+		printNonColorizedError(err)
+	}
+	panic(&printedErrorPanic{})
+}
+
 func printColorizedError(err error) {
 	stderr := os.Stderr
 	l := fmt.Sprintf("\x1b[%dm\xe2\x86\x93\x1b[0m", white)
@@ -435,6 +482,35 @@ func store() apitypes.Store {
 	return apiutils.NewStore()
 }
 
+// getVolumeCmd returns the "volume" command, creating and parenting it
+// onto the root command the first time it's needed. This tree doesn't
+// (yet) have a dedicated file that builds out the full volume command
+// tree, so callers that want to hang a subcommand off "rexray volume"
+// must go through this instead of assuming c.volumeCmd is already set.
+func (c *CLI) getVolumeCmd() *cobra.Command {
+	if c.volumeCmd == nil {
+		c.volumeCmd = &cobra.Command{
+			Use:   "volume",
+			Short: "The volume command",
+		}
+		c.c.AddCommand(c.volumeCmd)
+	}
+	return c.volumeCmd
+}
+
+// getServiceCmd returns the "service" command, creating and parenting it
+// onto the root command the first time it's needed. See getVolumeCmd.
+func (c *CLI) getServiceCmd() *cobra.Command {
+	if c.serviceCmd == nil {
+		c.serviceCmd = &cobra.Command{
+			Use:   "service",
+			Short: "The service command",
+		}
+		c.c.AddCommand(c.serviceCmd)
+	}
+	return c.serviceCmd
+}
+
 func checkOpPerms(op string) error {
 	//if os.Geteuid() != 0 {
 	//	return goof.Newf("REX-Ray can only be %s by root", op)
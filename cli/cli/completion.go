@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+
+	"github.com/codedellemc/rexray/util"
+)
+
+func init() {
+	initCmdFuncs = append(initCmdFuncs, func(c *CLI) {
+		c.initCompletionCmd()
+	})
+}
+
+func (c *CLI) initCompletionCmd() {
+	c.completionCmd = &cobra.Command{
+		Use:       "completion",
+		Short:     "Generate a shell completion script",
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		Args:      cobra.ExactValidArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			switch args[0] {
+			case "bash":
+				err = c.c.GenBashCompletion(os.Stdout)
+			case "zsh":
+				err = c.c.GenZshCompletion(os.Stdout)
+			case "fish":
+				err = c.c.GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				err = c.c.GenPowerShellCompletion(os.Stdout)
+			}
+			if err != nil {
+				c.fail(err)
+			}
+		},
+	}
+	c.c.AddCommand(c.completionCmd)
+}
+
+// initDynamicCompletion wires ValidArgsFunction callbacks onto the existing
+// command tree so that volume IDs/names, snapshot IDs, service names,
+// module types, and --format values can be completed by querying the
+// libStorage client at completion time.
+//
+// This is deliberately NOT registered through initCmdFuncs: those run in
+// file-registration order, so a command allocated by a later file would
+// still be nil here. NewWithArgs calls this explicitly after every
+// initCmdFuncs entry has run. Each callback is attached only to a command
+// that is non-nil at that point; a command that no initCmdFuncs entry
+// creates gets no dynamic completion.
+func (c *CLI) initDynamicCompletion() {
+	volumeNames := func(
+		cmd *cobra.Command, args []string, toComplete string) (
+		[]string, cobra.ShellCompDirective) {
+		return c.completeVolumeNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for _, cmd := range []*cobra.Command{
+		c.volumeRemoveCmd, c.volumeAttachCmd, c.volumeDetachCmd,
+		c.volumeMountCmd, c.volumeUnmountCmd, c.volumePathCmd,
+	} {
+		if cmd != nil {
+			cmd.ValidArgsFunction = volumeNames
+		}
+	}
+
+	snapshotIDs := func(
+		cmd *cobra.Command, args []string, toComplete string) (
+		[]string, cobra.ShellCompDirective) {
+		return c.completeSnapshotIDs(toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for _, cmd := range []*cobra.Command{
+		c.snapshotRemoveCmd, c.snapshotCopyCmd,
+	} {
+		if cmd != nil {
+			cmd.ValidArgsFunction = snapshotIDs
+		}
+	}
+
+	if c.serviceStartCmd != nil {
+		c.serviceStartCmd.ValidArgsFunction = c.completeServiceNames
+	}
+
+	if c.moduleInstancesCreateCmd != nil {
+		c.moduleInstancesCreateCmd.ValidArgsFunction = c.completeModuleTypes
+	}
+
+	c.c.RegisterFlagCompletionFunc("format", c.completeOutputFormats)
+}
+
+// ensureLibStorageActivatedForCompletion lazily activates libStorage the
+// first time a dynamic completion callback needs c.r. Completion callbacks
+// are invoked by cobra's __complete machinery, which calls
+// ValidArgsFunction/RegisterFlagCompletionFunc hooks directly and never
+// runs Persistent/PreRun hooks, so c.r is otherwise always nil at
+// completion time. Activation failures are swallowed: a shell asking for
+// completions should see "no suggestions", not a printed error.
+func (c *CLI) ensureLibStorageActivatedForCompletion() {
+	if c.r != nil || c.completionActivateFailed {
+		return
+	}
+
+	c.updateLogLevel()
+
+	var err error
+	c.ctx, c.config, c.rsErrs, err = util.ActivateLibStorage(c.ctx, c.config)
+	if err == nil {
+		c.r, err = util.NewClient(c.ctx, c.config)
+	}
+	if err != nil {
+		c.completionActivateFailed = true
+	}
+}
+
+func (c *CLI) completeVolumeNames(toComplete string) []string {
+	c.ensureLibStorageActivatedForCompletion()
+	if c.r == nil {
+		return nil
+	}
+	vols, err := c.r.Storage().Volumes(
+		c.ctx, &apitypes.VolumesOpts{Opts: store()})
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, 2*len(vols))
+	for _, vol := range vols {
+		names = append(names, vol.Name)
+		if vol.ID != vol.Name {
+			names = append(names, vol.ID)
+		}
+	}
+	return names
+}
+
+func (c *CLI) completeSnapshotIDs(toComplete string) []string {
+	c.ensureLibStorageActivatedForCompletion()
+	if c.r == nil {
+		return nil
+	}
+	snaps, err := c.r.Storage().Snapshots(
+		c.ctx, store())
+	if err != nil {
+		return nil
+	}
+	ids := make([]string, 0, len(snaps))
+	for _, snap := range snaps {
+		ids = append(ids, snap.ID)
+	}
+	return ids
+}
+
+func (c *CLI) completeServiceNames(
+	cmd *cobra.Command, args []string, toComplete string) (
+	[]string, cobra.ShellCompDirective) {
+
+	c.ensureLibStorageActivatedForCompletion()
+	if c.r == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	svcs, err := c.r.Services(c.ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(svcs))
+	for name := range svcs {
+		names = append(names, name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (c *CLI) completeModuleTypes(
+	cmd *cobra.Command, args []string, toComplete string) (
+	[]string, cobra.ShellCompDirective) {
+
+	c.ensureLibStorageActivatedForCompletion()
+	if c.r == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	types, err := c.r.API().Modules(c.ctx, store())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, t.Type)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (c *CLI) completeOutputFormats(
+	cmd *cobra.Command, args []string, toComplete string) (
+	[]string, cobra.ShellCompDirective) {
+	return []string{"tmpl", "json", "jsonp", "yaml", "table"},
+		cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"os/signal"
+	"text/template"
+
+	"github.com/akutz/goof"
+	"github.com/spf13/cobra"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+)
+
+func init() {
+	initCmdFuncs = append(initCmdFuncs, func(c *CLI) {
+		c.initVolumeExecCmd()
+	})
+}
+
+func (c *CLI) initVolumeExecCmd() {
+	c.volumeExecCmd = &cobra.Command{
+		Use: "exec",
+		Short: "Attach+mount a volume, run a command against its mount " +
+			"point, then unmount+detach it",
+		Example: "rexray volume exec myvol -- tar czf - {{.Mount}}",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runVolumeExec(args); err != nil {
+				c.fail(err)
+			}
+		},
+		PreRun: c.preRunActivateLibStorage,
+	}
+	c.getVolumeCmd().AddCommand(c.volumeExecCmd)
+
+	c.volumeExecCmd.Flags().BoolVarP(
+		&c.keep, "keep", "", false,
+		"Leave the volume attached and mounted on exit")
+	c.volumeExecCmd.Flags().BoolVarP(
+		&c.sigProxy, "sig-proxy", "", true,
+		"Proxy signals received by rexray to the exec'd command")
+	c.volumeExecCmd.Flags().BoolVarP(
+		&c.noStdin, "no-stdin", "", false,
+		"Do not attach the exec'd command's stdin")
+	c.volumeExecCmd.Flags().StringVarP(
+		&c.detachKeys, "detach-keys", "", "",
+		"Reserved for future interactive detach support")
+	c.addDryRunFlag(c.volumeExecCmd.Flags())
+}
+
+func (c *CLI) runVolumeExec(args []string) error {
+	if len(args) < 2 {
+		return goof.New(
+			"usage: rexray volume exec <volume> -- <command> [args...]")
+	}
+
+	volumeName := args[0]
+	cmdArgs := args[1:]
+
+	opStore := store()
+
+	vol, err := c.volumeByName(opStore, volumeName)
+	if err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		return nil
+	}
+
+	mountPoint, attachedHere, mountedHere, err := c.ensureAttachedAndMounted(
+		opStore, vol)
+	if err != nil {
+		return err
+	}
+
+	if !c.keep {
+		defer c.cleanupVolume(opStore, vol.ID, attachedHere, mountedHere)
+	}
+
+	resolvedArgs, err := resolveMountArgs(cmdArgs, mountPoint)
+	if err != nil {
+		return err
+	}
+
+	return c.execWithSignalProxy(resolvedArgs)
+}
+
+func (c *CLI) volumeByName(
+	store apitypes.Store, name string) (*apitypes.Volume, error) {
+
+	vols, err := c.r.Storage().Volumes(
+		c.ctx, &apitypes.VolumesOpts{
+			Attachments: apitypes.VolAttReqTrue, Opts: store})
+	if err != nil {
+		return nil, err
+	}
+	for _, vol := range vols {
+		if vol.Name == name {
+			return vol, nil
+		}
+	}
+	return nil, goof.WithField("volume", name).New("volume not found")
+}
+
+// ensureAttachedAndMounted attaches and/or mounts vol as needed to make it
+// available at a mount point, and reports back which of those two actions
+// it actually performed (attachedHere, mountedHere) so the caller can undo
+// only what this invocation did, not state that predates it.
+func (c *CLI) ensureAttachedAndMounted(
+	store apitypes.Store, vol *apitypes.Volume) (
+	mountPoint string, attachedHere bool, mountedHere bool, err error) {
+
+	if len(vol.Attachments) == 0 {
+		if _, _, err := c.r.Storage().VolumeAttach(
+			c.ctx, vol.ID, &apitypes.VolumeAttachOpts{Opts: store}); err != nil {
+			return "", false, false, err
+		}
+		attachedHere = true
+	}
+
+	mountPoint = c.mountPoint
+	if mountPoint == "" {
+		mountPoint = defaultMountPoint(vol.Name)
+	}
+
+	for _, a := range vol.Attachments {
+		if a.MountPoint == mountPoint {
+			// Already mounted at the target path before this invocation;
+			// nothing to do, and nothing for cleanup to undo.
+			return mountPoint, attachedHere, false, nil
+		}
+	}
+
+	if _, err := c.r.Storage().VolumeMount(
+		c.ctx, vol.ID, mountPoint,
+		&apitypes.VolumeMountOpts{NewFSType: c.fsType, Opts: store}); err != nil {
+		if attachedHere {
+			c.r.Storage().VolumeDetach(
+				c.ctx, vol.ID, &apitypes.VolumeDetachOpts{Opts: store})
+		}
+		return "", false, false, err
+	}
+
+	return mountPoint, attachedHere, true, nil
+}
+
+// cleanupVolume undoes only what ensureAttachedAndMounted actually did for
+// this invocation: it won't unmount/detach a volume that was already
+// attached+mounted before `rexray volume exec` ran.
+func (c *CLI) cleanupVolume(
+	store apitypes.Store, volumeID string, attachedHere, mountedHere bool) {
+
+	if mountedHere {
+		c.r.Storage().VolumeUnmount(c.ctx, volumeID, store)
+	}
+	if attachedHere {
+		c.r.Storage().VolumeDetach(
+			c.ctx, volumeID, &apitypes.VolumeDetachOpts{Opts: store})
+	}
+}
+
+func defaultMountPoint(volumeName string) string {
+	return "/var/lib/rexray/volumes/" + volumeName
+}
+
+// resolveMountArgs substitutes the {{.Mount}} template action in each
+// argument with mountPoint.
+func resolveMountArgs(args []string, mountPoint string) ([]string, error) {
+	data := struct{ Mount string }{Mount: mountPoint}
+
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		tmpl, err := template.New("arg").Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		resolved[i] = buf.String()
+	}
+	return resolved, nil
+}
+
+func (c *CLI) execWithSignalProxy(args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if !c.noStdin {
+		cmd.Stdin = os.Stdin
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if !c.sigProxy {
+		return cmd.Wait()
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh)
+	defer signal.Stop(sigCh)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	for {
+		select {
+		case sig := <-sigCh:
+			if cmd.Process != nil {
+				cmd.Process.Signal(sig)
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package manifest defines the declarative volume manifest format used by
+// the `rexray apply` and `rexray generate` commands and provides helpers
+// for loading and saving it.
+package manifest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// VolumeSpec describes the desired state of a single volume.
+type VolumeSpec struct {
+	Name             string            `json:"name" yaml:"name"`
+	Size             int64             `json:"size,omitempty" yaml:"size,omitempty"`
+	IOPS             int64             `json:"iops,omitempty" yaml:"iops,omitempty"`
+	Type             string            `json:"type,omitempty" yaml:"type,omitempty"`
+	Encrypted        bool              `json:"encrypted,omitempty" yaml:"encrypted,omitempty"`
+	EncryptionKey    string            `json:"encryptionKey,omitempty" yaml:"encryptionKey,omitempty"`
+	MountPoint       string            `json:"mountPoint,omitempty" yaml:"mountPoint,omitempty"`
+	FsType           string            `json:"fsType,omitempty" yaml:"fsType,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	AvailabilityZone string            `json:"availabilityZone,omitempty" yaml:"availabilityZone,omitempty"`
+	SnapshotSource   string            `json:"snapshotSource,omitempty" yaml:"snapshotSource,omitempty"`
+}
+
+// Manifest is the top-level document describing a set of volumes.
+type Manifest struct {
+	Version string       `json:"version" yaml:"version"`
+	Volumes []VolumeSpec `json:"volumes" yaml:"volumes"`
+}
+
+// Load reads a Manifest from the file at path. The format (YAML or JSON) is
+// inferred from the file extension, defaulting to YAML.
+func Load(path string) (*Manifest, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if isJSON(path) {
+		if err := json.Unmarshal(buf, m); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	for i := range m.Volumes {
+		if m.Volumes[i].Labels == nil {
+			m.Volumes[i].Labels = map[string]string{}
+		}
+	}
+
+	return m, nil
+}
+
+// Save writes the Manifest to the file at path, using YAML unless path ends
+// in ".json".
+func Save(path string, m *Manifest) error {
+	var (
+		buf []byte
+		err error
+	)
+
+	if isJSON(path) {
+		buf, err = json.MarshalIndent(m, "", "  ")
+	} else {
+		buf, err = yaml.Marshal(m)
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "manifest-test")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestSaveLoadRoundTripYAML(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	want := &Manifest{
+		Version: "v1",
+		Volumes: []VolumeSpec{
+			{
+				Name:   "vol1",
+				Size:   10,
+				IOPS:   100,
+				Type:   "gp2",
+				Labels: map[string]string{"env": "test"},
+			},
+		},
+	}
+
+	path := filepath.Join(dir, "manifest.yml")
+	require.NoError(t, Save(path, want))
+
+	got, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, want.Version, got.Version)
+	assert.Equal(t, want.Volumes, got.Volumes)
+}
+
+func TestSaveLoadRoundTripJSON(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	want := &Manifest{
+		Version: "v1",
+		Volumes: []VolumeSpec{
+			{
+				Name:           "vol1",
+				Size:           10,
+				SnapshotSource: "snap-1",
+				Labels:         map[string]string{},
+			},
+		},
+	}
+
+	path := filepath.Join(dir, "manifest.json")
+	require.NoError(t, Save(path, want))
+
+	got, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, want.Volumes, got.Volumes)
+}
+
+func TestLoadDefaultsNilLabelsToEmptyMap(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "manifest.yml")
+	require.NoError(t, ioutil.WriteFile(
+		path, []byte("version: v1\nvolumes:\n- name: vol1\n"), 0644))
+
+	got, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, got.Volumes, 1)
+	assert.NotNil(t, got.Volumes[0].Labels)
+	assert.Empty(t, got.Volumes[0].Labels)
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load("/no/such/manifest.yml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/akutz/goof"
+)
+
+// marshalOutput renders v to w according to c.outputFormat, honoring
+// --template, --table, and --templateTabs.
+func (c *CLI) marshalOutput(w io.Writer, v interface{}) error {
+	switch strings.ToLower(c.outputFormat) {
+	case "json":
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(buf, '\n'))
+		return err
+	case "jsonp":
+		buf, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(buf, '\n'))
+		return err
+	case "yaml":
+		buf, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	case "table":
+		if c.outputTable == "" {
+			return goof.New("--table is required when --format=table")
+		}
+		return c.execTableTemplate(w, unescapeTableSpec(c.outputTable), v)
+	case "tmpl", "":
+		tmpl, err := c.loadOutputTemplate()
+		if err != nil {
+			return err
+		}
+		return c.execTemplate(w, tmpl, v)
+	default:
+		return goof.WithField("format", c.outputFormat).New(
+			"unknown output format")
+	}
+}
+
+// loadOutputTemplate returns the configured --template value, reading it
+// from disk first if it is prefixed with "@".
+func (c *CLI) loadOutputTemplate() (string, error) {
+	if !strings.HasPrefix(c.outputTemplate, "@") {
+		return c.outputTemplate, nil
+	}
+
+	path := strings.TrimPrefix(c.outputTemplate, "@")
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// unescapeTableSpec converts the literal "\t"/"\n" escape sequences a user
+// types on the command line (e.g. --table '{{.Name}}\t{{.Size}}') into real
+// tab/newline bytes before the spec is parsed as a Go template.
+func unescapeTableSpec(spec string) string {
+	r := strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+	return r.Replace(spec)
+}
+
+func (c *CLI) execTemplate(w io.Writer, text string, v interface{}) error {
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return err
+	}
+
+	if !c.outputTemplateTabs {
+		return tmpl.Execute(w, v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if err := tmpl.Execute(tw, v); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// execTableTemplate parses spec as a Go template and, when v is a slice or
+// array (e.g. the []applyResultRow rows rendered by `rexray apply`),
+// executes it once per element so a per-row --table spec like
+// "{{.Name}}\t{{.Action}}\t{{.Result}}" renders one line per item instead
+// of failing with "can't evaluate field ... in type []...". Non-slice
+// values are executed against spec directly as a single line. Every
+// rendered line ends with a newline.
+func (c *CLI) execTableTemplate(w io.Writer, spec string, v interface{}) error {
+	tmpl, err := template.New("table").Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	dest := w
+	var tw *tabwriter.Writer
+	if c.outputTemplateTabs {
+		tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		dest = tw
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			if err := tmpl.Execute(dest, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(dest, "\n"); err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := tmpl.Execute(dest, v); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(dest, "\n"); err != nil {
+			return err
+		}
+	}
+
+	if tw == nil {
+		return nil
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintApplyResultsDefaultTableSpec(t *testing.T) {
+	c := &CLI{}
+
+	var buf bytes.Buffer
+	rows := []applyResultRow{
+		{Name: "vol1", Action: "created", Result: "ok"},
+		{Name: "vol2", Action: "unchanged", Result: "ok"},
+	}
+
+	require.NoError(t, c.execTableTemplate(
+		&buf, unescapeTableSpec(defaultApplyTableSpec), rows))
+
+	assert.Equal(
+		t, "vol1\tcreated\tok\nvol2\tunchanged\tok\n", buf.String())
+}
+
+func TestExecTableTemplateSingleValue(t *testing.T) {
+	c := &CLI{}
+
+	var buf bytes.Buffer
+	row := applyResultRow{Name: "vol1", Action: "created", Result: "ok"}
+
+	require.NoError(t, c.execTableTemplate(&buf, defaultApplyTableSpec, row))
+	assert.Equal(t, "vol1\tcreated\tok\n", buf.String())
+}
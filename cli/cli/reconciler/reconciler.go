@@ -0,0 +1,220 @@
+// Package reconciler diffs a manifest's desired volume state against the
+// volumes actually visible to a libStorage client and drives the client
+// towards the desired state.
+package reconciler
+
+import (
+	"strings"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+
+	"github.com/codedellemc/rexray/cli/cli/manifest"
+)
+
+// Action describes what the reconciler did, or would do, for a single
+// volume.
+type Action string
+
+const (
+	// ActionNone indicates the volume already matched the desired state.
+	ActionNone Action = "unchanged"
+	// ActionCreate indicates a volume was, or would be, created.
+	ActionCreate Action = "created"
+	// ActionResize indicates an existing volume's size differs from the
+	// manifest. The drift is reported only; the volume is not resized.
+	ActionResize Action = "resized"
+	// ActionRetag indicates an existing volume's labels differ from the
+	// manifest. The drift is reported only; the labels are not updated.
+	ActionRetag Action = "retagged"
+	// ActionAttach indicates a volume was, or would be, attached and
+	// mounted.
+	ActionAttach Action = "attached"
+)
+
+// Result is the outcome of reconciling a single volume.
+type Result struct {
+	Name   string
+	Action Action
+	Error  error
+}
+
+// StorageDriver is the subset of apitypes.StorageDriver the reconciler
+// depends on. It exists so tests can exercise Reconciler against a fake
+// without having to satisfy libStorage's full driver interface.
+type StorageDriver interface {
+	Volumes(
+		ctx apitypes.Context,
+		opts *apitypes.VolumesOpts) ([]*apitypes.Volume, error)
+	VolumeCreate(
+		ctx apitypes.Context,
+		name string,
+		opts *apitypes.VolumeCreateOpts) (*apitypes.Volume, error)
+	VolumeCreateFromSnapshot(
+		ctx apitypes.Context,
+		snapshotID, volumeName string,
+		opts *apitypes.VolumeCreateOpts) (*apitypes.Volume, error)
+	VolumeAttach(
+		ctx apitypes.Context,
+		volumeID string,
+		opts *apitypes.VolumeAttachOpts) (*apitypes.Volume, string, error)
+	VolumeMount(
+		ctx apitypes.Context,
+		volumeID, mountPoint string,
+		opts *apitypes.VolumeMountOpts) (string, error)
+}
+
+// Reconciler drives libStorage volumes towards the state described by a
+// manifest.
+type Reconciler struct {
+	Storage         StorageDriver
+	DryRun          bool
+	ContinueOnError bool
+	// Idempotent makes the attach/mount step tolerate a volume that's
+	// already attached or mounted: an error from VolumeAttach/VolumeMount
+	// is swallowed only when isAlreadyAttachedOrMountedErr identifies it as
+	// that specific condition, so re-running `rexray apply` against
+	// volumes it already converged doesn't fail on state it itself left
+	// behind. Any other error (permission denied, volume busy, a network
+	// error, ...) is still reported regardless of Idempotent.
+	Idempotent bool
+	Attach     bool
+}
+
+// New returns a new Reconciler backed by client's storage driver.
+func New(client apitypes.Client) *Reconciler {
+	return &Reconciler{Storage: client.Storage()}
+}
+
+// Reconcile diffs the desired volumes against the volumes visible to the
+// client, creates the missing ones, reports size and label drift on
+// existing ones without changing them, and optionally attaches+mounts them.
+func (r *Reconciler) Reconcile(
+	ctx apitypes.Context,
+	store apitypes.Store,
+	desired []manifest.VolumeSpec) ([]Result, error) {
+
+	existing, err := r.Storage.Volumes(
+		ctx, &apitypes.VolumesOpts{Opts: store})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*apitypes.Volume{}
+	for _, vol := range existing {
+		byName[vol.Name] = vol
+	}
+
+	results := make([]Result, 0, len(desired))
+	for _, spec := range desired {
+		res := r.reconcileOne(ctx, store, spec, byName[spec.Name])
+		results = append(results, res)
+		if res.Error != nil && !r.ContinueOnError {
+			return results, res.Error
+		}
+	}
+
+	return results, nil
+}
+
+func (r *Reconciler) reconcileOne(
+	ctx apitypes.Context,
+	store apitypes.Store,
+	spec manifest.VolumeSpec,
+	actual *apitypes.Volume) Result {
+
+	res := Result{Name: spec.Name, Action: ActionNone}
+
+	if actual == nil {
+		res.Action = ActionCreate
+		if r.DryRun {
+			return res
+		}
+
+		opts := &apitypes.VolumeCreateOpts{
+			Encrypted:     &spec.Encrypted,
+			EncryptionKey: &spec.EncryptionKey,
+			IOPS:          &spec.IOPS,
+			Size:          &spec.Size,
+			Type:          &spec.Type,
+			Opts:          store,
+		}
+		if spec.AvailabilityZone != "" {
+			opts.AvailabilityZone = &spec.AvailabilityZone
+		}
+
+		var (
+			vol *apitypes.Volume
+			err error
+		)
+		if spec.SnapshotSource != "" {
+			vol, err = r.Storage.VolumeCreateFromSnapshot(
+				ctx, spec.SnapshotSource, spec.Name, opts)
+		} else {
+			vol, err = r.Storage.VolumeCreate(ctx, spec.Name, opts)
+		}
+		if err != nil {
+			res.Error = err
+			return res
+		}
+		actual = vol
+	} else if driftsFromSize(actual, spec) {
+		// Resizing an existing volume isn't supported by the storage
+		// driver interface yet; report the drift instead of silently
+		// taking the wrong action.
+		res.Action = ActionResize
+	} else if driftsFromLabels(actual, spec) {
+		res.Action = ActionRetag
+	}
+
+	if r.Attach && spec.MountPoint != "" {
+		res.Action = ActionAttach
+		if !r.DryRun {
+			if _, _, err := r.Storage.VolumeAttach(
+				ctx, actual.ID,
+				&apitypes.VolumeAttachOpts{Opts: store}); err != nil &&
+				!(r.Idempotent && isAlreadyAttachedOrMountedErr(err)) {
+				res.Error = err
+				return res
+			}
+			if _, err := r.Storage.VolumeMount(
+				ctx, actual.ID, spec.MountPoint,
+				&apitypes.VolumeMountOpts{
+					NewFSType: spec.FsType,
+					Opts:      store,
+				}); err != nil &&
+				!(r.Idempotent && isAlreadyAttachedOrMountedErr(err)) {
+				res.Error = err
+				return res
+			}
+		}
+	}
+
+	return res
+}
+
+func driftsFromSize(actual *apitypes.Volume, spec manifest.VolumeSpec) bool {
+	return spec.Size > 0 && actual.Size != spec.Size
+}
+
+func driftsFromLabels(actual *apitypes.Volume, spec manifest.VolumeSpec) bool {
+	for k, v := range spec.Labels {
+		if actual.Fields[k] != v {
+			return true
+		}
+	}
+	return false
+}
+
+// isAlreadyAttachedOrMountedErr reports whether err represents a volume
+// already being attached or mounted, as opposed to any other failure (a
+// permissions error, a busy device, a network error, ...). libStorage
+// drivers don't expose a typed sentinel for this, so the specific
+// condition is identified from the error text.
+func isAlreadyAttachedOrMountedErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already attached") ||
+		strings.Contains(msg, "already mounted")
+}
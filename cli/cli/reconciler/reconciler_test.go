@@ -0,0 +1,248 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+
+	"github.com/codedellemc/rexray/cli/cli/manifest"
+)
+
+type fakeStorageDriver struct {
+	volumes         []*apitypes.Volume
+	createErr       error
+	attachErr       error
+	mountErr        error
+	createCalls     int
+	createSnapCalls int
+	attachCalls     int
+	mountCalls      int
+	lastSnapshotID  string
+}
+
+func (f *fakeStorageDriver) Volumes(
+	ctx apitypes.Context,
+	opts *apitypes.VolumesOpts) ([]*apitypes.Volume, error) {
+	return f.volumes, nil
+}
+
+func (f *fakeStorageDriver) VolumeCreate(
+	ctx apitypes.Context,
+	name string,
+	opts *apitypes.VolumeCreateOpts) (*apitypes.Volume, error) {
+	f.createCalls++
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+	vol := &apitypes.Volume{ID: name + "-id", Name: name}
+	f.volumes = append(f.volumes, vol)
+	return vol, nil
+}
+
+func (f *fakeStorageDriver) VolumeCreateFromSnapshot(
+	ctx apitypes.Context,
+	snapshotID, volumeName string,
+	opts *apitypes.VolumeCreateOpts) (*apitypes.Volume, error) {
+	f.createSnapCalls++
+	f.lastSnapshotID = snapshotID
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+	vol := &apitypes.Volume{ID: volumeName + "-id", Name: volumeName}
+	f.volumes = append(f.volumes, vol)
+	return vol, nil
+}
+
+func (f *fakeStorageDriver) VolumeAttach(
+	ctx apitypes.Context,
+	volumeID string,
+	opts *apitypes.VolumeAttachOpts) (*apitypes.Volume, string, error) {
+	f.attachCalls++
+	return nil, "", f.attachErr
+}
+
+func (f *fakeStorageDriver) VolumeMount(
+	ctx apitypes.Context,
+	volumeID, mountPoint string,
+	opts *apitypes.VolumeMountOpts) (string, error) {
+	f.mountCalls++
+	return mountPoint, f.mountErr
+}
+
+func TestReconcileCreatesMissingVolume(t *testing.T) {
+	f := &fakeStorageDriver{}
+	r := &Reconciler{Storage: f}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", Size: 10},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, ActionCreate, results[0].Action)
+	assert.Equal(t, 1, f.createCalls)
+	assert.Equal(t, 0, f.createSnapCalls)
+}
+
+func TestReconcileRestoresFromSnapshotSource(t *testing.T) {
+	f := &fakeStorageDriver{}
+	r := &Reconciler{Storage: f}
+
+	_, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", SnapshotSource: "snap-123"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.createSnapCalls)
+	assert.Equal(t, 0, f.createCalls)
+	assert.Equal(t, "snap-123", f.lastSnapshotID)
+}
+
+func TestReconcileDryRunSkipsCreate(t *testing.T) {
+	f := &fakeStorageDriver{}
+	r := &Reconciler{Storage: f, DryRun: true}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionCreate, results[0].Action)
+	assert.Equal(t, 0, f.createCalls)
+}
+
+func TestReconcileReportsSizeDriftWithoutMutating(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes: []*apitypes.Volume{{ID: "vol1-id", Name: "vol1", Size: 5}},
+	}
+	r := &Reconciler{Storage: f}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", Size: 10},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionResize, results[0].Action)
+	assert.Nil(t, results[0].Error)
+	assert.Equal(t, 0, f.createCalls)
+}
+
+func TestReconcileReportsLabelDrift(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes: []*apitypes.Volume{
+			{ID: "vol1-id", Name: "vol1", Fields: map[string]string{}},
+		},
+	}
+	r := &Reconciler{Storage: f}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", Labels: map[string]string{"env": "prod"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionRetag, results[0].Action)
+}
+
+func TestReconcileUnchangedWhenNoDrift(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes: []*apitypes.Volume{{ID: "vol1-id", Name: "vol1", Size: 10}},
+	}
+	r := &Reconciler{Storage: f}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", Size: 10},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionNone, results[0].Action)
+}
+
+func TestReconcileAttachesAndMountsWhenRequested(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes: []*apitypes.Volume{{ID: "vol1-id", Name: "vol1"}},
+	}
+	r := &Reconciler{Storage: f, Attach: true}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", MountPoint: "/mnt/vol1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionAttach, results[0].Action)
+	assert.Equal(t, 1, f.attachCalls)
+	assert.Equal(t, 1, f.mountCalls)
+}
+
+func TestReconcileAttachErrorsFailWithoutIdempotent(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes:   []*apitypes.Volume{{ID: "vol1-id", Name: "vol1"}},
+		attachErr: assert.AnError,
+	}
+	r := &Reconciler{Storage: f, Attach: true}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", MountPoint: "/mnt/vol1"},
+	})
+	assert.Error(t, err)
+	require.Len(t, results, 1)
+	assert.Error(t, results[0].Error)
+	assert.Equal(t, 0, f.mountCalls)
+}
+
+func TestReconcileIdempotentSwallowsAlreadyAttachedAndMountedErrors(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes:   []*apitypes.Volume{{ID: "vol1-id", Name: "vol1"}},
+		attachErr: goof.New("volume already attached to instance"),
+		mountErr:  goof.New("path already mounted"),
+	}
+	r := &Reconciler{Storage: f, Attach: true, Idempotent: true}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", MountPoint: "/mnt/vol1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.NoError(t, results[0].Error)
+	assert.Equal(t, ActionAttach, results[0].Action)
+	assert.Equal(t, 1, f.attachCalls)
+	assert.Equal(t, 1, f.mountCalls)
+}
+
+func TestReconcileIdempotentStillPropagatesUnrelatedErrors(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes:   []*apitypes.Volume{{ID: "vol1-id", Name: "vol1"}},
+		attachErr: assert.AnError,
+	}
+	r := &Reconciler{Storage: f, Attach: true, Idempotent: true}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1", MountPoint: "/mnt/vol1"},
+	})
+	assert.Error(t, err)
+	require.Len(t, results, 1)
+	assert.Error(t, results[0].Error)
+	assert.Equal(t, 0, f.mountCalls)
+}
+
+func TestReconcileStopsOnErrorWithoutContinueOnError(t *testing.T) {
+	f := &fakeStorageDriver{createErr: assert.AnError}
+	r := &Reconciler{Storage: f}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1"},
+		{Name: "vol2"},
+	})
+	assert.Error(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestReconcileContinuesOnErrorWhenConfigured(t *testing.T) {
+	f := &fakeStorageDriver{createErr: assert.AnError}
+	r := &Reconciler{Storage: f, ContinueOnError: true}
+
+	results, err := r.Reconcile(nil, nil, []manifest.VolumeSpec{
+		{Name: "vol1"},
+		{Name: "vol2"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Error(t, results[0].Error)
+	assert.Error(t, results[1].Error)
+}
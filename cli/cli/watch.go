@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+	"github.com/spf13/cobra"
+
+	"github.com/codedellemc/rexray/cli/cli/watcher"
+)
+
+func init() {
+	initCmdFuncs = append(initCmdFuncs, func(c *CLI) {
+		c.initVolumeWatchCmd()
+		c.initServiceWatchCmd()
+	})
+}
+
+func (c *CLI) initVolumeWatchCmd() {
+	c.volumeWatchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Watch declared volumes and reconcile attach/mount drift",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runWatch(); err != nil {
+				c.fail(err)
+			}
+		},
+		PreRun: c.preRunActivateLibStorage,
+	}
+	c.getVolumeCmd().AddCommand(c.volumeWatchCmd)
+	c.addWatchFlags(c.volumeWatchCmd)
+	c.addContinueOnErrorFlag(c.volumeWatchCmd.Flags())
+
+	c.initVolumeWatchAddCmd()
+	c.initVolumeWatchListCmd()
+	c.initVolumeWatchRemoveCmd()
+}
+
+func (c *CLI) initVolumeWatchAddCmd() {
+	c.volumeWatchAddCmd = &cobra.Command{
+		Use:   "add <volumeName>",
+		Short: "Declare that a volume must always be attached and mounted",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runWatchAdd(args[0]); err != nil {
+				c.fail(err)
+			}
+		},
+	}
+	c.volumeWatchCmd.AddCommand(c.volumeWatchAddCmd)
+
+	c.volumeWatchAddCmd.Flags().StringVarP(
+		&c.mountPoint, "mountPoint", "", "",
+		"The path the volume must always be mounted at")
+	c.volumeWatchAddCmd.Flags().StringVarP(
+		&c.fsType, "fsType", "", "",
+		"The file system type to use if the volume is formatted")
+}
+
+func (c *CLI) initVolumeWatchListCmd() {
+	c.volumeWatchListCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List declared volume watch policies",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runWatchList(); err != nil {
+				c.fail(err)
+			}
+		},
+	}
+	c.volumeWatchCmd.AddCommand(c.volumeWatchListCmd)
+}
+
+func (c *CLI) initVolumeWatchRemoveCmd() {
+	c.volumeWatchRemoveCmd = &cobra.Command{
+		Use:   "rm <volumeName>",
+		Short: "Remove a declared volume watch policy",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runWatchRemove(args[0]); err != nil {
+				c.fail(err)
+			}
+		},
+	}
+	c.volumeWatchCmd.AddCommand(c.volumeWatchRemoveCmd)
+}
+
+func (c *CLI) initServiceWatchCmd() {
+	c.serviceWatchCmd = &cobra.Command{
+		Use: "watch",
+		Short: "Run a long-lived process that watches declared volumes " +
+			"and reconciles attach/mount drift",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := c.runWatch(); err != nil {
+				c.fail(err)
+			}
+		},
+		PreRun: c.preRunActivateLibStorage,
+	}
+	c.getServiceCmd().AddCommand(c.serviceWatchCmd)
+	c.addWatchFlags(c.serviceWatchCmd)
+	c.addContinueOnErrorFlag(c.serviceWatchCmd.Flags())
+}
+
+func (c *CLI) addWatchFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVarP(
+		&c.watchInterval, "interval", "", 30*time.Second,
+		"How often to reconcile attach/mount drift")
+	cmd.Flags().BoolVarP(
+		&c.watchOnce, "once", "", false,
+		"Reconcile once and exit, for use from cron")
+}
+
+func (c *CLI) runWatch() error {
+	dir, err := c.watchPolicyDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := watcher.NewPolicyStore(dir)
+	if err != nil {
+		return err
+	}
+
+	policies, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return goof.WithField("dir", dir).New(
+			"no volume watch policies declared")
+	}
+
+	w := watcher.New(c.r, store, c.watchInterval)
+	w.ContinueOnError = c.continueOnError
+	w.Events = os.Stdout
+
+	return w.Run(c.ctx, c.watchOnce)
+}
+
+func (c *CLI) runWatchAdd(volumeName string) error {
+	if c.mountPoint == "" {
+		return goof.New("--mountPoint is required")
+	}
+
+	dir, err := c.watchPolicyDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := watcher.NewPolicyStore(dir)
+	if err != nil {
+		return err
+	}
+
+	return store.Save(watcher.Policy{
+		VolumeName: volumeName,
+		MountPoint: c.mountPoint,
+		FsType:     c.fsType,
+	})
+}
+
+func (c *CLI) runWatchList() error {
+	dir, err := c.watchPolicyDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := watcher.NewPolicyStore(dir)
+	if err != nil {
+		return err
+	}
+
+	policies, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "VOLUME\tMOUNTPOINT\tFSTYPE")
+	for _, p := range policies {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", p.VolumeName, p.MountPoint, p.FsType)
+	}
+	return tw.Flush()
+}
+
+func (c *CLI) runWatchRemove(volumeName string) error {
+	dir, err := c.watchPolicyDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := watcher.NewPolicyStore(dir)
+	if err != nil {
+		return err
+	}
+
+	return store.Remove(volumeName)
+}
+
+func (c *CLI) watchPolicyDir() (string, error) {
+	home, err := gotil.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".rexray", "volume-watch"), nil
+}
@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PolicyStore persists Policies as one JSON file per volume, under Dir.
+type PolicyStore struct {
+	Dir string
+}
+
+// NewPolicyStore returns a PolicyStore rooted at dir, creating dir if it
+// does not already exist.
+func NewPolicyStore(dir string) (*PolicyStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &PolicyStore{Dir: dir}, nil
+}
+
+// Save persists p, keyed by p.VolumeName.
+func (s *PolicyStore) Save(p Policy) error {
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path(p.VolumeName), buf, 0644)
+}
+
+// Remove deletes the policy for the given volume name, if any.
+func (s *PolicyStore) Remove(volumeName string) error {
+	err := os.Remove(s.path(volumeName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// List returns all stored policies.
+func (s *PolicyStore) List() ([]Policy, error) {
+	entries, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]Policy, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		buf, err := ioutil.ReadFile(filepath.Join(s.Dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var p Policy
+		if err := json.Unmarshal(buf, &p); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func (s *PolicyStore) path(volumeName string) string {
+	return filepath.Join(s.Dir, volumeName+".json")
+}
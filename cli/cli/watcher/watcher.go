@@ -0,0 +1,159 @@
+// Package watcher implements a periodic reconciler that keeps a declared
+// set of volumes attached and mounted, re-attaching and remounting them
+// when it detects drift.
+package watcher
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/akutz/goof"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+	apiutils "github.com/codedellemc/libstorage/api/utils"
+)
+
+// Policy declares that a volume must always be attached to the current
+// instance and mounted at MountPoint.
+type Policy struct {
+	VolumeName string `json:"volumeName"`
+	MountPoint string `json:"mountPoint"`
+	FsType     string `json:"fsType,omitempty"`
+}
+
+// Event is a single structured, newline-delimited JSON record describing
+// what the watcher observed or did for a policy.
+type Event struct {
+	Time   string `json:"time"`
+	Volume string `json:"volume"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// StorageDriver is the subset of apitypes.StorageDriver the watcher
+// depends on. It exists so tests can exercise Watcher against a fake
+// without having to satisfy libStorage's full driver interface.
+type StorageDriver interface {
+	Volumes(
+		ctx apitypes.Context,
+		opts *apitypes.VolumesOpts) ([]*apitypes.Volume, error)
+	VolumeAttach(
+		ctx apitypes.Context,
+		volumeID string,
+		opts *apitypes.VolumeAttachOpts) (*apitypes.Volume, string, error)
+	VolumeMount(
+		ctx apitypes.Context,
+		volumeID, mountPoint string,
+		opts *apitypes.VolumeMountOpts) (string, error)
+}
+
+// Watcher periodically reconciles a set of Policies against the volumes
+// visible to Storage.
+type Watcher struct {
+	Storage         StorageDriver
+	Store           *PolicyStore
+	Interval        time.Duration
+	ContinueOnError bool
+	Events          io.Writer
+	Now             func() time.Time
+}
+
+// New returns a new Watcher that reconciles the policies in store against
+// client every interval.
+func New(client apitypes.Client, store *PolicyStore, interval time.Duration) *Watcher {
+	return &Watcher{
+		Storage:  client.Storage(),
+		Store:    store,
+		Interval: interval,
+		Now:      time.Now,
+	}
+}
+
+// Run reconciles every known policy once, and then, unless once is true,
+// again every w.Interval until ctx is done.
+func (w *Watcher) Run(ctx apitypes.Context, once bool) error {
+	for {
+		policies, err := w.Store.List()
+		if err != nil {
+			return err
+		}
+
+		for _, p := range policies {
+			err := w.reconcile(ctx, p)
+			w.emit(p, err)
+			if err != nil && !w.ContinueOnError {
+				return err
+			}
+		}
+
+		if once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.Interval):
+		}
+	}
+}
+
+func (w *Watcher) reconcile(ctx apitypes.Context, p Policy) error {
+	store := apiutils.NewStore()
+
+	vols, err := w.Storage.Volumes(
+		ctx, &apitypes.VolumesOpts{Attachments: apitypes.VolAttReqTrue, Opts: store})
+	if err != nil {
+		return err
+	}
+
+	var vol *apitypes.Volume
+	for _, v := range vols {
+		if v.Name == p.VolumeName {
+			vol = v
+			break
+		}
+	}
+	if vol == nil {
+		return goof.WithField("volume", p.VolumeName).New(
+			"policy volume not found")
+	}
+
+	if len(vol.Attachments) == 0 {
+		if _, _, err := w.Storage.VolumeAttach(
+			ctx, vol.ID, &apitypes.VolumeAttachOpts{Opts: store}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.Storage.VolumeMount(
+		ctx, vol.ID, p.MountPoint,
+		&apitypes.VolumeMountOpts{NewFSType: p.FsType, Opts: store}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *Watcher) emit(p Policy, err error) {
+	if w.Events == nil {
+		return
+	}
+
+	ev := Event{
+		Time:   w.Now().UTC().Format(time.RFC3339),
+		Volume: p.VolumeName,
+		Action: "reconciled",
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+
+	buf, mErr := json.Marshal(ev)
+	if mErr != nil {
+		return
+	}
+	buf = append(buf, '\n')
+	w.Events.Write(buf)
+}
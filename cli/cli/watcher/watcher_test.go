@@ -0,0 +1,152 @@
+package watcher
+
+import (
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+)
+
+type fakeStorageDriver struct {
+	volumes     []*apitypes.Volume
+	attachErr   error
+	mountErr    error
+	attachCalls int
+	mountCalls  int
+}
+
+func (f *fakeStorageDriver) Volumes(
+	ctx apitypes.Context,
+	opts *apitypes.VolumesOpts) ([]*apitypes.Volume, error) {
+	return f.volumes, nil
+}
+
+func (f *fakeStorageDriver) VolumeAttach(
+	ctx apitypes.Context,
+	volumeID string,
+	opts *apitypes.VolumeAttachOpts) (*apitypes.Volume, string, error) {
+	f.attachCalls++
+	return nil, "", f.attachErr
+}
+
+func (f *fakeStorageDriver) VolumeMount(
+	ctx apitypes.Context,
+	volumeID, mountPoint string,
+	opts *apitypes.VolumeMountOpts) (string, error) {
+	f.mountCalls++
+	return mountPoint, f.mountErr
+}
+
+func TestReconcileReturnsErrorWhenVolumeNotFound(t *testing.T) {
+	f := &fakeStorageDriver{}
+	w := &Watcher{Storage: f}
+
+	err := w.reconcile(nil, Policy{VolumeName: "vol1", MountPoint: "/mnt/vol1"})
+	assert.Error(t, err)
+	assert.Equal(t, 0, f.attachCalls)
+	assert.Equal(t, 0, f.mountCalls)
+}
+
+func TestReconcileAttachesWhenNotAttached(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes: []*apitypes.Volume{{ID: "vol1-id", Name: "vol1"}},
+	}
+	w := &Watcher{Storage: f}
+
+	err := w.reconcile(nil, Policy{VolumeName: "vol1", MountPoint: "/mnt/vol1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.attachCalls)
+	assert.Equal(t, 1, f.mountCalls)
+}
+
+func TestReconcileSkipsAttachWhenAlreadyAttached(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes: []*apitypes.Volume{
+			{
+				ID:   "vol1-id",
+				Name: "vol1",
+				Attachments: []*apitypes.VolumeAttachment{
+					{MountPoint: "/mnt/vol1"},
+				},
+			},
+		},
+	}
+	w := &Watcher{Storage: f}
+
+	err := w.reconcile(nil, Policy{VolumeName: "vol1", MountPoint: "/mnt/vol1"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, f.attachCalls)
+	assert.Equal(t, 1, f.mountCalls)
+}
+
+func TestReconcileReturnsAttachError(t *testing.T) {
+	f := &fakeStorageDriver{
+		volumes:   []*apitypes.Volume{{ID: "vol1-id", Name: "vol1"}},
+		attachErr: assert.AnError,
+	}
+	w := &Watcher{Storage: f}
+
+	err := w.reconcile(nil, Policy{VolumeName: "vol1", MountPoint: "/mnt/vol1"})
+	assert.Error(t, err)
+	assert.Equal(t, 0, f.mountCalls)
+}
+
+func TestRunStopsOnErrorWithoutContinueOnError(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewPolicyStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(Policy{VolumeName: "vol1", MountPoint: "/mnt/vol1"}))
+	require.NoError(t, store.Save(Policy{VolumeName: "vol2", MountPoint: "/mnt/vol2"}))
+
+	f := &fakeStorageDriver{}
+	w := &Watcher{Storage: f, Store: store, Now: time.Now}
+
+	err = w.Run(nil, true)
+	assert.Error(t, err)
+	assert.Equal(t, 0, f.attachCalls)
+}
+
+func TestRunContinuesOnErrorWhenConfigured(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewPolicyStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(Policy{VolumeName: "vol1", MountPoint: "/mnt/vol1"}))
+	require.NoError(t, store.Save(Policy{VolumeName: "vol2", MountPoint: "/mnt/vol2"}))
+
+	f := &fakeStorageDriver{}
+	w := &Watcher{Storage: f, Store: store, ContinueOnError: true, Now: time.Now}
+
+	require.NoError(t, w.Run(nil, true))
+}
+
+func TestPolicyStoreSaveListRemoveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewPolicyStore(dir)
+	require.NoError(t, err)
+
+	p := Policy{VolumeName: "vol1", MountPoint: "/mnt/vol1", FsType: "ext4"}
+	require.NoError(t, store.Save(p))
+
+	policies, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, p, policies[0])
+
+	require.NoError(t, store.Remove(p.VolumeName))
+
+	policies, err = store.List()
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+}
+
+func TestPolicyStoreRemoveMissingPolicyIsNotAnError(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewPolicyStore(dir)
+	require.NoError(t, err)
+
+	assert.NoError(t, store.Remove("no-such-volume"))
+}